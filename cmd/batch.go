@@ -0,0 +1,141 @@
+/*
+MIT License
+
+Copyright (c) 2021 Jacob Salmela <me@jacobsalmela.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	batchWorkers int
+	batchSeed    int64
+)
+
+// batchCmd generates art for many source images concurrently
+var batchCmd = &cobra.Command{
+	Use:   "batch PATTERN",
+	Short: "Generate art for many source images concurrently",
+	Long: `Generates art for every source image matched by PATTERN, which may
+	be a glob (e.g. "photos/*.jpg") or a directory of images.
+
+	Up to --workers source images are processed at a time. Each image's
+	sketch is seeded from --seed plus its index in the batch, so a batch
+	run is fully reproducible regardless of how the workers happen to get
+	scheduled.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		files, err := batchSources(args[0])
+		if err != nil {
+			log.Panicln(err)
+		}
+
+		if !cmd.Flags().Changed("seed") {
+			batchSeed = time.Now().UnixNano()
+		}
+		fmt.Fprintln(os.Stderr, "Using base seed", batchSeed)
+
+		runBatch(files, batchSeed, destWidth, destHeight, jpegQuality)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(batchCmd)
+	batchCmd.DisableAutoGenTag = true
+
+	addGenerationFlags(batchCmd)
+	batchCmd.Flags().IntVarP(&batchWorkers, "workers", "w", runtime.NumCPU(), "number of source images to process concurrently")
+	batchCmd.Flags().Int64Var(&batchSeed, "seed", 0, "base seed; each file's sketch is seeded from this plus its index (default: time-based)")
+}
+
+// batchSources expands pattern into the list of source image paths to
+// process. If pattern names a directory, every file directly inside it is
+// used; otherwise pattern is treated as a glob.
+func batchSources(pattern string) ([]string, error) {
+	if info, err := os.Stat(pattern); err == nil && info.IsDir() {
+		entries, err := os.ReadDir(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		var files []string
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				files = append(files, filepath.Join(pattern, entry.Name()))
+			}
+		}
+		return files, nil
+	}
+
+	return filepath.Glob(pattern)
+}
+
+// runBatch generates art for each of files, distributing the work across a
+// pool of batchWorkers goroutines. Each file's sketch is seeded from
+// baseSeed plus its index in files, rather than sharing a single global
+// *rand.Rand, so the workers never race on random state. width, height, and
+// quality are passed straight through to generateArt rather than read from
+// package globals inside the goroutines, for the same reason.
+func runBatch(files []string, baseSeed int64, width, height, quality int) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, batchWorkers)
+
+	for i, src := range files {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(i int, src string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			dest := batchDestFor(src)
+			fmt.Fprintln(os.Stderr, "Generating...", dest)
+
+			rng := rand.New(rand.NewSource(baseSeed + int64(i)))
+			if err := generateArt(src, dest, rng, width, height, quality); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", src, err)
+			}
+		}(i, src)
+	}
+
+	wg.Wait()
+}
+
+// batchDestFor mirrors the default output naming used by the generate
+// command for a single source image.
+func batchDestFor(src string) string {
+	basename := filepath.Base(src)
+	basename = strings.TrimSuffix(basename, filepath.Ext(basename)) + "-goart." + format
+	return filepath.Join(filepath.Dir(src), basename)
+}
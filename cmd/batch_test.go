@@ -0,0 +1,132 @@
+/*
+MIT License
+
+Copyright (c) 2021 Jacob Salmela <me@jacobsalmela.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+package cmd
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBatchSourcesDirectory(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.png", "b.png"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o644); err != nil {
+			t.Fatalf("failed to seed %s: %v", name, err)
+		}
+	}
+
+	files, err := batchSources(dir)
+	if err != nil {
+		t.Fatalf("batchSources returned an error: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("batchSources found %d files, want 2", len(files))
+	}
+}
+
+func TestBatchSourcesGlob(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.jpg"), []byte("x"), 0o644); err != nil {
+		t.Fatalf("failed to seed file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.png"), []byte("x"), 0o644); err != nil {
+		t.Fatalf("failed to seed file: %v", err)
+	}
+
+	files, err := batchSources(filepath.Join(dir, "*.png"))
+	if err != nil {
+		t.Fatalf("batchSources returned an error: %v", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("batchSources matched %d files, want 1", len(files))
+	}
+}
+
+func TestBatchDestFor(t *testing.T) {
+	format = "png"
+	got := batchDestFor("/photos/vacation.jpg")
+	want := "/photos/vacation-goart.png"
+	if got != want {
+		t.Errorf("batchDestFor = %q, want %q", got, want)
+	}
+}
+
+// TestRunBatchIsRaceFree drives runBatch across several concurrent workers
+// on source images of different sizes, the scenario that used to corrupt
+// generateArt's width/height through shared package globals. Run with
+// `go test -race` to confirm no data race is reported.
+func TestRunBatchIsRaceFree(t *testing.T) {
+	dir := t.TempDir()
+	var files []string
+	sizes := []int{2, 4, 6, 8}
+	for i, size := range sizes {
+		path := filepath.Join(dir, "img"+string(rune('a'+i))+".png")
+		if err := writeTestPNG(path, size, size); err != nil {
+			t.Fatalf("failed to write test image: %v", err)
+		}
+		files = append(files, path)
+	}
+
+	format = "png"
+	keepSourceDimensions = false
+	totalCycleCount = 1
+	minEdge, maxEdge = 3, 4
+	strokeRatio = 0.5
+	initialAlpha = 0.1
+	strokeReduction = 0.01
+	alphaIncrease = 0.1
+	strokeInversionThreshold = 0.05
+	strokeJitter = 0.1
+	resample = "nearest"
+	batchWorkers = 4
+
+	runBatch(files, 1, 3, 3, 90)
+
+	for _, src := range files {
+		if _, err := os.Stat(batchDestFor(src)); err != nil {
+			t.Errorf("expected output for %s, got error: %v", src, err)
+		}
+	}
+}
+
+func writeTestPNG(path string, w, h int) error {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 10), G: uint8(y * 10), B: 128, A: 255})
+		}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return png.Encode(f, img)
+}
@@ -20,14 +20,15 @@ AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
 LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
 OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
 SOFTWARE.
-
 */
 package cmd
 
 import (
 	"fmt"
 	"image"
-	"image/png"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
 	"log"
 	"math/rand"
 	"os"
@@ -35,16 +36,26 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jacobsalmela/goart/filter"
+	goartio "github.com/jacobsalmela/goart/io"
 	"github.com/jacobsalmela/goart/sketch"
 	"github.com/spf13/cobra"
+	xdraw "golang.org/x/image/draw"
 )
 
 var (
 	sourceImgName                                                                                     string
-	outputImgName                                                                                     string
+	outputImgName, outputFlag, format                                                                 string
+	jpegQuality                                                                                       int
 	strokeRatio, initialAlpha, strokeReduction, alphaIncrease, strokeInversionThreshold, strokeJitter float64
 	destWidth, destHeight, minEdge, maxEdge, totalCycleCount                                          int
-	keepSourceDimensions                                                                              bool
+	keepSourceDimensions, luminanceScale                                                              bool
+	animateOut                                                                                        string
+	frameInterval, frameDelay, loopCount                                                              int
+	seed                                                                                              int64
+	postprocess                                                                                       string
+	resample                                                                                          string
+	upscale                                                                                           float64
 )
 
 // generateCmd generates a new image from a source image
@@ -52,24 +63,46 @@ var generateCmd = &cobra.Command{
 	Use:   "generate FILE",
 	Short: "Generate a art from a source image",
 	Long: `Generates art using a source image as the starting point.
-	
+
 	The generated image can be further manipulated with flags to this
-	command.`,
+	command.
+
+	FILE may be - to read the source image from stdin.`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		// The source image is the first argument
 		sourceImgName = args[0]
-		// Get just the filename
-		sourceBasename := filepath.Base(sourceImgName)
-		// Trim the extension and append the new extension
-		sourceBasename = strings.TrimSuffix(sourceBasename, filepath.Ext(sourceBasename)) + "-goart.png"
-		// Save to the directry of the source image by default
-		sourceDir := filepath.Dir(sourceImgName)
-		// Save to the same director as the source image
-		outputImgName = filepath.Join(sourceDir, sourceBasename)
-		fmt.Println("Generating...", outputImgName)
+
+		if outputFlag != "" {
+			// The user gave an explicit output path (possibly "-" for stdout)
+			outputImgName = outputFlag
+		} else if sourceImgName == "-" {
+			// There's no source filename to derive a default from, so fall
+			// back to stdout
+			outputImgName = "-"
+		} else {
+			// Get just the filename
+			sourceBasename := filepath.Base(sourceImgName)
+			// Trim the extension and append the new extension
+			sourceBasename = strings.TrimSuffix(sourceBasename, filepath.Ext(sourceBasename)) + "-goart." + format
+			// Save to the directry of the source image by default
+			sourceDir := filepath.Dir(sourceImgName)
+			// Save to the same director as the source image
+			outputImgName = filepath.Join(sourceDir, sourceBasename)
+		}
+
+		fmt.Fprintln(os.Stderr, "Generating...", outputImgName)
+
+		// A user-supplied --seed makes the run reproducible; otherwise pick
+		// a fresh one based on the current time, same as before.
+		if !cmd.Flags().Changed("seed") {
+			seed = time.Now().UnixNano()
+		}
+		fmt.Fprintln(os.Stderr, "Using seed", seed)
+
 		// Generate the art
-		err := generateArt(sourceImgName, outputImgName)
+		rng := rand.New(rand.NewSource(seed))
+		err := generateArt(sourceImgName, outputImgName, rng, destWidth, destHeight, jpegQuality)
 		if err != nil {
 			log.Panicln(err)
 		}
@@ -80,35 +113,105 @@ func init() {
 	rootCmd.AddCommand(generateCmd)
 	generateCmd.DisableAutoGenTag = true
 
-	generateCmd.Flags().Float64VarP(&strokeRatio, "stroke-ratio", "r", 0.75, "size of the initial stroke compared to that of the final result")
-	generateCmd.Flags().BoolVarP(&keepSourceDimensions, "keep-source-dimensions", "K", true, "generate a new image with the same dimensions as the source image")
-	generateCmd.Flags().IntVarP(&destWidth, "width", "W", 2000, "width of the generated image")
-	generateCmd.Flags().IntVarP(&destHeight, "height", "H", 2000, "height of the generated image")
-	generateCmd.Flags().Float64VarP(&initialAlpha, "initial-alpha", "a", 0.1, "beginning stroke transparency")
-	generateCmd.Flags().Float64VarP(&strokeReduction, "stroke-reduction", "R", 0.002, "the initial stroke size gets minimized by this amount on each iteration")
-	generateCmd.Flags().Float64VarP(&alphaIncrease, "alpha-increase", "A", 0.06, "the step of transparency increase at each iteration")
-	generateCmd.Flags().Float64VarP(&strokeInversionThreshold, "stroke-inversion-threshold", "t", 0.05, "the minimum stroke size")
-	generateCmd.Flags().Float64VarP(&strokeJitter, "stroke-jitter", "j", 0.1, "deviation of the colored stroke from its projected position in the original image")
-	generateCmd.Flags().IntVarP(&minEdge, "min-edge", "m", 3, "minimum stroke will be a n-edge polygon (3 is a triangle)")
-	generateCmd.Flags().IntVarP(&maxEdge, "max-edge", "M", 4, "maximum stroke will be a n-edge polygon (4 is a square)")
-	generateCmd.Flags().IntVarP(&totalCycleCount, "total-cycles", "T", 5000, "Copy any discovered k8s squashfs images from SRC to DEST")
+	addGenerationFlags(generateCmd)
+	generateCmd.Flags().StringVarP(&outputFlag, "output", "o", "", "path to save the generated image to, or - for stdout (default: <source>-goart.<format>)")
+	generateCmd.Flags().StringVar(&animateOut, "animate", "", "capture generation progress and save it as an animated GIF to this path")
+	generateCmd.Flags().IntVar(&frameInterval, "frame-interval", 50, "capture an animation frame every N iterations, used with --animate")
+	generateCmd.Flags().IntVar(&frameDelay, "frame-delay", 4, "delay between animation frames, in hundredths of a second, used with --animate")
+	generateCmd.Flags().IntVar(&loopCount, "loop", 0, "number of times the animation restarts after its first play; 0 loops forever and -1 plays it once, used with --animate")
+	generateCmd.Flags().Int64Var(&seed, "seed", 0, "seed for the sketch's random number generator (default: time-based)")
 }
 
-// generateArt generates a new image from a source image
-func generateArt(sourcePath string, destPath string) error {
+// addGenerationFlags registers the flags that control the generation
+// algorithm and its output, as opposed to a command's own I/O or
+// concurrency flags. It is shared by generateCmd and batchCmd so that
+// every parameter introduced for single-file generation is also available
+// when processing a batch of images.
+func addGenerationFlags(cmd *cobra.Command) {
+	cmd.Flags().Float64VarP(&strokeRatio, "stroke-ratio", "r", 0.75, "size of the initial stroke compared to that of the final result")
+	cmd.Flags().BoolVarP(&keepSourceDimensions, "keep-source-dimensions", "K", true, "generate a new image with the same dimensions as the source image")
+	cmd.Flags().IntVarP(&destWidth, "width", "W", 2000, "width of the generated image")
+	cmd.Flags().IntVarP(&destHeight, "height", "H", 2000, "height of the generated image")
+	cmd.Flags().Float64VarP(&initialAlpha, "initial-alpha", "a", 0.1, "beginning stroke transparency")
+	cmd.Flags().Float64VarP(&strokeReduction, "stroke-reduction", "R", 0.002, "the initial stroke size gets minimized by this amount on each iteration")
+	cmd.Flags().Float64VarP(&alphaIncrease, "alpha-increase", "A", 0.06, "the step of transparency increase at each iteration")
+	cmd.Flags().Float64VarP(&strokeInversionThreshold, "stroke-inversion-threshold", "t", 0.05, "the minimum stroke size; the sketch does not read it and draws no stroke borders")
+	cmd.Flags().Float64VarP(&strokeJitter, "stroke-jitter", "j", 0.1, "deviation of the colored stroke from its projected position in the original image")
+	cmd.Flags().IntVarP(&minEdge, "min-edge", "m", 3, "minimum stroke will be a n-edge polygon (3 is a triangle)")
+	cmd.Flags().IntVarP(&maxEdge, "max-edge", "M", 4, "maximum stroke will be a n-edge polygon (4 is a square)")
+	cmd.Flags().IntVarP(&totalCycleCount, "total-cycles", "T", 5000, "number of iterations to run; each iteration paints one stroke")
+	cmd.Flags().BoolVar(&luminanceScale, "luminance-scale", false, "scale each stroke's size by the brightness of the pixel it samples, using the source as a luminance heatmap")
+	cmd.Flags().StringVarP(&format, "format", "f", "png", "output image format: png, jpeg, gif, or bmp")
+	cmd.Flags().IntVarP(&jpegQuality, "jpeg-quality", "q", 90, "JPEG quality (1-100), used when --format=jpeg")
+	cmd.Flags().StringVar(&postprocess, "postprocess", "", "comma-separated post-processing pipeline applied to the finished sketch, e.g. blur=1.5,sharpen=0.8,gamma=2.2,greyscale")
+	cmd.Flags().StringVar(&resample, "resample", "nearest", "interpolator used to scale the source image and the final output: nearest, bilinear, or catmullrom")
+	cmd.Flags().Float64Var(&upscale, "upscale", 1, "factor to upscale the finished sketch by before saving, using --resample's interpolator")
+}
+
+// resampler returns the sketch.Resampler backed by the golang.org/x/image/draw
+// interpolator named by name.
+func resampler(name string) (sketch.Resampler, error) {
+	switch name {
+	case "nearest":
+		return drawResampler{xdraw.NearestNeighbor}, nil
+	case "bilinear":
+		return drawResampler{xdraw.ApproxBiLinear}, nil
+	case "catmullrom":
+		return drawResampler{xdraw.CatmullRom}, nil
+	default:
+		return nil, fmt.Errorf("unrecognized --resample interpolator %q", name)
+	}
+}
+
+// drawResampler adapts a golang.org/x/image/draw.Interpolator to the
+// sketch.Resampler interface.
+type drawResampler struct {
+	interpolator xdraw.Interpolator
+}
+
+func (r drawResampler) Resample(img image.Image, width, height int) image.Image {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	r.interpolator.Scale(dst, dst.Bounds(), img, img.Bounds(), xdraw.Over, nil)
+	return dst
+}
+
+// generateArt generates a new image from a source image, drawing all of its
+// randomness from rng. width, height, and quality are passed in explicitly,
+// rather than read from the destWidth/destHeight/jpegQuality package
+// globals, so that concurrent callers (cmd/batch.go) never race on shared
+// state.
+func generateArt(sourcePath string, destPath string, rng *rand.Rand, width, height, quality int) error {
 
 	// load the source image
-	img, err := loadImage(sourcePath)
+	img, err := goartio.Load(sourcePath)
 
 	if err != nil {
-		log.Panicln(err)
+		return err
 	}
 
 	// if the user wants to keep the source dimensions,
 	if keepSourceDimensions {
 		// set them appropriately
-		destWidth = img.Bounds().Dx()
-		destHeight = img.Bounds().Dy()
+		width = img.Bounds().Dx()
+		height = img.Bounds().Dy()
+	}
+
+	// needsResize is true when the source actually has to be scaled to
+	// reach width x height, as opposed to a plain --keep-source-dimensions
+	// run where no pre-scaling pass is needed.
+	needsResize := width != img.Bounds().Dx() || height != img.Bounds().Dy()
+
+	var scaler sketch.Resampler
+	if needsResize || upscale > 1 {
+		scaler, err = resampler(resample)
+		if err != nil {
+			return err
+		}
+	}
+
+	var preScaler sketch.Resampler
+	if needsResize {
+		preScaler = scaler
 	}
 
 	// StrokeRatio determines the size of the initial stroke compared
@@ -165,27 +268,57 @@ func generateArt(sourcePath string, destPath string) error {
 	//
 	s := sketch.NewSketch(img, sketch.UserParams{
 		StrokeRatio:              strokeRatio,
-		DestWidth:                destWidth,
-		DestHeight:               destHeight,
+		DestWidth:                width,
+		DestHeight:               height,
 		InitialAlpha:             initialAlpha,
 		StrokeReduction:          strokeReduction,
 		AlphaIncrease:            alphaIncrease,
 		StrokeInversionThreshold: strokeInversionThreshold,
-		StrokeJitter:             int(strokeJitter * float64(destWidth)),
+		StrokeJitter:             int(strokeJitter * float64(width)),
 		MinEdgeCount:             minEdge,
 		MaxEdgeCount:             maxEdge,
-	})
+		LuminanceScale:           luminanceScale,
+		Resampler:                preScaler,
+	}, rng)
 
-	rand.Seed(time.Now().Unix())
+	if animateOut != "" && frameInterval <= 0 {
+		return fmt.Errorf("--frame-interval must be positive, got %d", frameInterval)
+	}
+
+	var frames []image.Image
 
 	// for each iteration defined,
 	for i := 0; i < totalCycleCount; i++ {
 		// update the sketch
 		s.Update()
+
+		if animateOut != "" && i%frameInterval == 0 {
+			frames = append(frames, s.Snapshot())
+		}
+	}
+
+	if animateOut != "" {
+		if err := saveAnimation(frames, animateOut); err != nil {
+			return err
+		}
+	}
+
+	output := s.Output()
+
+	if upscale > 1 {
+		bounds := output.Bounds()
+		output = scaler.Resample(output, int(float64(bounds.Dx())*upscale), int(float64(bounds.Dy())*upscale))
+	}
+
+	if postprocess != "" {
+		output, err = filter.Apply(output, postprocess)
+		if err != nil {
+			return err
+		}
 	}
 
 	// save the sketch once it is generated
-	err = saveOutput(s.Output(), destPath)
+	err = goartio.Save(output, destPath, format, quality)
 
 	if err != nil {
 		return err
@@ -194,27 +327,22 @@ func generateArt(sourcePath string, destPath string) error {
 	return nil
 }
 
-// loadImage loads an image from the given path
-func loadImage(src string) (image.Image, error) {
-	file, _ := os.Open(sourceImgName)
-	defer file.Close()
-	img, _, err := image.Decode(file)
-	return img, err
-}
-
-// saveOutput saves the output image to the specified path
-func saveOutput(img image.Image, filePath string) error {
-	f, err := os.Create(filePath)
+// saveAnimation encodes frames as an animated GIF, in the order captured,
+// and writes it to dest.
+func saveAnimation(frames []image.Image, dest string) error {
+	f, err := os.Create(dest)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
 
-	// Encode to `PNG` with `DefaultCompression` level then save to a file
-	err = png.Encode(f, img)
-	if err != nil {
-		return err
+	anim := gif.GIF{LoopCount: loopCount}
+	for _, frame := range frames {
+		paletted := image.NewPaletted(frame.Bounds(), palette.Plan9)
+		draw.FloydSteinberg.Draw(paletted, frame.Bounds(), frame, frame.Bounds().Min)
+		anim.Image = append(anim.Image, paletted)
+		anim.Delay = append(anim.Delay, frameDelay)
 	}
 
-	return nil
+	return gif.EncodeAll(f, &anim)
 }
@@ -0,0 +1,85 @@
+/*
+MIT License
+
+Copyright (c) 2021 Jacob Salmela <me@jacobsalmela.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package cmd
+
+import (
+	"image/gif"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateArtAnimateFrameCount(t *testing.T) {
+	dir := t.TempDir()
+	src := writeSourceImage(t, dir)
+	setBasicGenerationParams()
+
+	totalCycleCount = 10
+	frameInterval = 3
+	frameDelay = 4
+	loopCount = 0
+	animateOut = filepath.Join(dir, "out.gif")
+
+	dest := filepath.Join(dir, "out.png")
+	if err := generateArt(src, dest, rand.New(rand.NewSource(1)), 5, 5, 90); err != nil {
+		t.Fatalf("generateArt returned an error: %v", err)
+	}
+
+	f, err := os.Open(animateOut)
+	if err != nil {
+		t.Fatalf("failed to open animation: %v", err)
+	}
+	defer f.Close()
+
+	anim, err := gif.DecodeAll(f)
+	if err != nil {
+		t.Fatalf("failed to decode animation: %v", err)
+	}
+
+	want := 0
+	for i := 0; i < totalCycleCount; i++ {
+		if i%frameInterval == 0 {
+			want++
+		}
+	}
+	if len(anim.Image) != want {
+		t.Errorf("animation has %d frames, want %d", len(anim.Image), want)
+	}
+}
+
+func TestGenerateArtRejectsNonPositiveFrameInterval(t *testing.T) {
+	dir := t.TempDir()
+	src := writeSourceImage(t, dir)
+	setBasicGenerationParams()
+
+	frameInterval = 0
+	animateOut = filepath.Join(dir, "out.gif")
+
+	dest := filepath.Join(dir, "out.png")
+	err := generateArt(src, dest, rand.New(rand.NewSource(1)), 5, 5, 90)
+	if err == nil {
+		t.Fatal("expected an error for --frame-interval 0 with --animate set")
+	}
+}
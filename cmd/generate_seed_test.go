@@ -0,0 +1,118 @@
+/*
+MIT License
+
+Copyright (c) 2021 Jacob Salmela <me@jacobsalmela.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+package cmd
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// setBasicGenerationParams sets the package-level flag-bound vars that
+// generateArt reads to a small, fast configuration suitable for tests.
+func setBasicGenerationParams() {
+	keepSourceDimensions = false
+	totalCycleCount = 10
+	minEdge, maxEdge = 3, 4
+	strokeRatio = 0.5
+	initialAlpha = 0.1
+	strokeReduction = 0.01
+	alphaIncrease = 0.05
+	strokeInversionThreshold = 0.05
+	strokeJitter = 0.1
+	luminanceScale = false
+	resample = "nearest"
+	upscale = 1
+	postprocess = ""
+	format = "png"
+	animateOut = ""
+}
+
+func writeSourceImage(t *testing.T, dir string) string {
+	t.Helper()
+	path := filepath.Join(dir, "src.png")
+	if err := writeTestPNG(path, 6, 6); err != nil {
+		t.Fatalf("failed to write source image: %v", err)
+	}
+	return path
+}
+
+func TestGenerateArtSameSeedIsDeterministic(t *testing.T) {
+	dir := t.TempDir()
+	src := writeSourceImage(t, dir)
+	setBasicGenerationParams()
+
+	destA := filepath.Join(dir, "a.png")
+	destB := filepath.Join(dir, "b.png")
+
+	if err := generateArt(src, destA, rand.New(rand.NewSource(42)), 5, 5, 90); err != nil {
+		t.Fatalf("generateArt returned an error: %v", err)
+	}
+	if err := generateArt(src, destB, rand.New(rand.NewSource(42)), 5, 5, 90); err != nil {
+		t.Fatalf("generateArt returned an error: %v", err)
+	}
+
+	a, err := os.ReadFile(destA)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", destA, err)
+	}
+	b, err := os.ReadFile(destB)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", destB, err)
+	}
+	if !bytes.Equal(a, b) {
+		t.Fatal("expected generateArt runs with the same seed to produce byte-identical output")
+	}
+}
+
+func TestGenerateArtDifferentSeedDiverges(t *testing.T) {
+	dir := t.TempDir()
+	src := writeSourceImage(t, dir)
+	setBasicGenerationParams()
+
+	destA := filepath.Join(dir, "a.png")
+	destB := filepath.Join(dir, "b.png")
+
+	if err := generateArt(src, destA, rand.New(rand.NewSource(1)), 5, 5, 90); err != nil {
+		t.Fatalf("generateArt returned an error: %v", err)
+	}
+	if err := generateArt(src, destB, rand.New(rand.NewSource(2)), 5, 5, 90); err != nil {
+		t.Fatalf("generateArt returned an error: %v", err)
+	}
+
+	a, err := os.ReadFile(destA)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", destA, err)
+	}
+	b, err := os.ReadFile(destB)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", destB, err)
+	}
+	if bytes.Equal(a, b) {
+		t.Fatal("expected generateArt runs with different seeds to diverge")
+	}
+}
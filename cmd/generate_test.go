@@ -0,0 +1,59 @@
+/*
+MIT License
+
+Copyright (c) 2021 Jacob Salmela <me@jacobsalmela.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+package cmd
+
+import (
+	"image"
+
+	"testing"
+)
+
+func TestResamplerKnownNames(t *testing.T) {
+	for _, name := range []string{"nearest", "bilinear", "catmullrom"} {
+		if _, err := resampler(name); err != nil {
+			t.Errorf("resampler(%q) returned an error: %v", name, err)
+		}
+	}
+}
+
+func TestResamplerUnrecognizedName(t *testing.T) {
+	if _, err := resampler("lanczos"); err == nil {
+		t.Fatal("expected an error for an unrecognized interpolator name")
+	}
+}
+
+func TestDrawResamplerScalesToRequestedSize(t *testing.T) {
+	r, err := resampler("bilinear")
+	if err != nil {
+		t.Fatalf("resampler returned an error: %v", err)
+	}
+
+	src := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	out := r.Resample(src, 8, 2)
+	bounds := out.Bounds()
+	if bounds.Dx() != 8 || bounds.Dy() != 2 {
+		t.Fatalf("Resample produced a %dx%d image, want 8x2", bounds.Dx(), bounds.Dy())
+	}
+}
@@ -0,0 +1,290 @@
+/*
+MIT License
+
+Copyright (c) 2021 Jacob Salmela <me@jacobsalmela.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package filter implements the post-processing pipeline goart can apply to
+// a finished sketch: Gaussian blur, unsharp-mask sharpening, gamma
+// correction, and greyscale conversion.
+package filter
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Apply runs img through the pipeline described by spec and returns the
+// result. spec is a comma-separated list of stages, each either a bare name
+// ("greyscale") or a name=value pair ("blur=1.5"), applied in the order
+// given, e.g. "blur=1.5,sharpen=0.8,gamma=2.2,greyscale".
+func Apply(img image.Image, spec string) (image.Image, error) {
+	out := img
+
+	for _, stage := range strings.Split(spec, ",") {
+		stage = strings.TrimSpace(stage)
+		if stage == "" {
+			continue
+		}
+
+		name, arg, hasArg := strings.Cut(stage, "=")
+
+		switch name {
+		case "blur":
+			sigma, err := parseArg(name, arg, hasArg)
+			if err != nil {
+				return nil, err
+			}
+			out = Blur(out, sigma)
+		case "sharpen":
+			amount, err := parseArg(name, arg, hasArg)
+			if err != nil {
+				return nil, err
+			}
+			out = Sharpen(out, amount)
+		case "gamma":
+			gamma, err := parseArg(name, arg, hasArg)
+			if err != nil {
+				return nil, err
+			}
+			out = Gamma(out, gamma)
+		case "greyscale":
+			out = Greyscale(out)
+		default:
+			return nil, fmt.Errorf("filter: unrecognized stage %q", name)
+		}
+	}
+
+	return out, nil
+}
+
+// parseArg parses the float64 argument required by a stage.
+func parseArg(name, arg string, hasArg bool) (float64, error) {
+	if !hasArg {
+		return 0, fmt.Errorf("filter: stage %q requires a value, e.g. %s=1.5", name, name)
+	}
+	v, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return 0, fmt.Errorf("filter: stage %q has an invalid value %q: %w", name, arg, err)
+	}
+	return v, nil
+}
+
+// Blur applies a separable Gaussian blur with the given standard deviation.
+// A sigma of zero or less is a no-op.
+func Blur(img image.Image, sigma float64) image.Image {
+	if sigma <= 0 {
+		return img
+	}
+	return convolveSeparable(img, gaussianKernel(sigma))
+}
+
+// Sharpen applies an unsharp mask: out = src + amount*(src - blurred).
+func Sharpen(img image.Image, amount float64) image.Image {
+	src := toNRGBA(img)
+	blurred := toNRGBA(Blur(src, 1))
+
+	bounds := src.Bounds()
+	out := image.NewNRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			s := src.At(x, y).(color.NRGBA)
+			b := blurred.At(x, y).(color.NRGBA)
+			out.SetNRGBA(x, y, color.NRGBA{
+				R: unsharpChannel(s.R, b.R, amount),
+				G: unsharpChannel(s.G, b.G, amount),
+				B: unsharpChannel(s.B, b.B, amount),
+				A: s.A,
+			})
+		}
+	}
+	return out
+}
+
+// Gamma applies out = 255*(in/255)^(1/gamma) to each channel via a
+// precomputed lookup table, operating in linear light: each sample is
+// converted from sRGB to linear before the LUT is applied, then back to
+// sRGB afterward.
+func Gamma(img image.Image, gamma float64) image.Image {
+	lut := gammaLUT(gamma)
+
+	src := toNRGBA(img)
+	bounds := src.Bounds()
+	out := image.NewNRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := src.At(x, y).(color.NRGBA)
+			out.SetNRGBA(x, y, color.NRGBA{R: lut[c.R], G: lut[c.G], B: lut[c.B], A: c.A})
+		}
+	}
+	return out
+}
+
+// Greyscale converts img to greyscale using the Rec. 601 luma formula
+// 0.299R + 0.587G + 0.114B.
+func Greyscale(img image.Image) image.Image {
+	src := toNRGBA(img)
+	bounds := src.Bounds()
+	out := image.NewNRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := src.At(x, y).(color.NRGBA)
+			l := uint8(clampF(0.299*float64(c.R)+0.587*float64(c.G)+0.114*float64(c.B), 0, 255))
+			out.SetNRGBA(x, y, color.NRGBA{R: l, G: l, B: l, A: c.A})
+		}
+	}
+	return out
+}
+
+// gaussianKernel returns a normalized 1D Gaussian kernel for standard
+// deviation sigma, with size ceil(3*sigma)*2+1.
+func gaussianKernel(sigma float64) []float64 {
+	radius := int(math.Ceil(3 * sigma))
+	kernel := make([]float64, radius*2+1)
+
+	sum := 0.0
+	for i := range kernel {
+		x := float64(i - radius)
+		kernel[i] = math.Exp(-(x * x) / (2 * sigma * sigma))
+		sum += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+	return kernel
+}
+
+// convolveSeparable applies kernel along X then Y, clamping at the image
+// edges.
+func convolveSeparable(img image.Image, kernel []float64) image.Image {
+	src := toNRGBA(img)
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	radius := len(kernel) / 2
+
+	type pixel struct{ r, g, b, a float64 }
+	horizontal := make([]pixel, w*h)
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var p pixel
+			for k, weight := range kernel {
+				sx := clampInt(x+k-radius, 0, w-1)
+				c := src.At(bounds.Min.X+sx, bounds.Min.Y+y).(color.NRGBA)
+				p.r += float64(c.R) * weight
+				p.g += float64(c.G) * weight
+				p.b += float64(c.B) * weight
+				p.a += float64(c.A) * weight
+			}
+			horizontal[y*w+x] = p
+		}
+	}
+
+	out := image.NewNRGBA(bounds)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var p pixel
+			for k, weight := range kernel {
+				sy := clampInt(y+k-radius, 0, h-1)
+				s := horizontal[sy*w+x]
+				p.r += s.r * weight
+				p.g += s.g * weight
+				p.b += s.b * weight
+				p.a += s.a * weight
+			}
+			out.SetNRGBA(bounds.Min.X+x, bounds.Min.Y+y, color.NRGBA{
+				R: uint8(clampF(p.r, 0, 255)),
+				G: uint8(clampF(p.g, 0, 255)),
+				B: uint8(clampF(p.b, 0, 255)),
+				A: uint8(clampF(p.a, 0, 255)),
+			})
+		}
+	}
+	return out
+}
+
+func unsharpChannel(src, blurred uint8, amount float64) uint8 {
+	v := float64(src) + amount*(float64(src)-float64(blurred))
+	return uint8(clampF(v, 0, 255))
+}
+
+// gammaLUT precomputes the sRGB->linear->pow(1/gamma)->sRGB lookup table
+// for every possible input byte.
+func gammaLUT(gamma float64) [256]uint8 {
+	var lut [256]uint8
+	for i := 0; i < 256; i++ {
+		linear := srgbToLinear(float64(i) / 255)
+		adjusted := math.Pow(linear, 1/gamma)
+		lut[i] = uint8(clampF(linearToSRGB(adjusted)*255, 0, 255))
+	}
+	return lut
+}
+
+func srgbToLinear(c float64) float64 {
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(c float64) float64 {
+	if c <= 0.0031308 {
+		return c * 12.92
+	}
+	return 1.055*math.Pow(c, 1/2.4) - 0.055
+}
+
+// toNRGBA returns img as an *image.NRGBA, converting it if necessary.
+func toNRGBA(img image.Image) *image.NRGBA {
+	if n, ok := img.(*image.NRGBA); ok {
+		return n
+	}
+	bounds := img.Bounds()
+	out := image.NewNRGBA(bounds)
+	draw.Draw(out, bounds, img, bounds.Min, draw.Src)
+	return out
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+func clampF(v, lo, hi float64) float64 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
@@ -0,0 +1,134 @@
+/*
+MIT License
+
+Copyright (c) 2021 Jacob Salmela <me@jacobsalmela.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+package filter
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func checkerboard(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if (x+y)%2 == 0 {
+				img.SetNRGBA(x, y, color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+			} else {
+				img.SetNRGBA(x, y, color.NRGBA{A: 255})
+			}
+		}
+	}
+	return img
+}
+
+func TestBlurZeroSigmaIsNoOp(t *testing.T) {
+	src := checkerboard(4, 4)
+	out := Blur(src, 0)
+	if out != image.Image(src) {
+		t.Fatalf("Blur with sigma <= 0 should return img unchanged")
+	}
+}
+
+func TestBlurSmoothsCheckerboard(t *testing.T) {
+	src := checkerboard(8, 8)
+	out := toNRGBA(Blur(src, 1.5))
+
+	// a blurred checkerboard should pull every pixel toward grey, so no
+	// channel should still be pinned at 0 or 255 away from the edges.
+	c := out.NRGBAAt(4, 4)
+	if c.R == 0 || c.R == 255 {
+		t.Errorf("expected blur to soften pixel (4,4), got R=%d", c.R)
+	}
+}
+
+func TestGreyscaleRemovesChannelDifference(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	src.SetNRGBA(0, 0, color.NRGBA{R: 10, G: 200, B: 50, A: 255})
+
+	out := toNRGBA(Greyscale(src))
+	c := out.NRGBAAt(0, 0)
+	if c.R != c.G || c.G != c.B {
+		t.Fatalf("expected R==G==B after greyscale, got %+v", c)
+	}
+
+	want := uint8(clampF(0.299*10+0.587*200+0.114*50, 0, 255))
+	if c.R != want {
+		t.Errorf("greyscale luma = %d, want %d", c.R, want)
+	}
+}
+
+func TestGammaIsNearIdentityAtOne(t *testing.T) {
+	src := checkerboard(2, 2)
+	out := toNRGBA(Gamma(src, 1))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 2; x++ {
+			want := src.NRGBAAt(x, y)
+			got := out.NRGBAAt(x, y)
+			// the round trip through the sRGB<->linear LUT can be off by a
+			// rounding step, but should never move a channel by more than 1.
+			if diff := int(got.R) - int(want.R); diff > 1 || diff < -1 {
+				t.Errorf("Gamma(img, 1) at (%d,%d) = %+v, want ~%+v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestGammaAboveOneBrightens(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	src.SetNRGBA(0, 0, color.NRGBA{R: 128, G: 128, B: 128, A: 255})
+
+	out := toNRGBA(Gamma(src, 2.2))
+	c := out.NRGBAAt(0, 0)
+	if c.R <= 128 {
+		t.Errorf("Gamma(img, 2.2) should brighten a mid-grey pixel, got R=%d", c.R)
+	}
+}
+
+func TestApplyUnrecognizedStage(t *testing.T) {
+	src := checkerboard(2, 2)
+	if _, err := Apply(src, "frobnicate=1"); err == nil {
+		t.Fatal("expected an error for an unrecognized stage")
+	}
+}
+
+func TestApplyMissingValue(t *testing.T) {
+	src := checkerboard(2, 2)
+	if _, err := Apply(src, "blur"); err == nil {
+		t.Fatal("expected an error for a stage missing its required value")
+	}
+}
+
+func TestApplyPipeline(t *testing.T) {
+	src := checkerboard(4, 4)
+	out, err := Apply(src, "blur=1,greyscale")
+	if err != nil {
+		t.Fatalf("Apply returned an error: %v", err)
+	}
+	c := toNRGBA(out).NRGBAAt(2, 2)
+	if c.R != c.G || c.G != c.B {
+		t.Fatalf("expected a greyscale result after the pipeline, got %+v", c)
+	}
+}
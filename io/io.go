@@ -0,0 +1,95 @@
+/*
+MIT License
+
+Copyright (c) 2021 Jacob Salmela <me@jacobsalmela.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package io loads source images and saves generated sketches. Loading
+// detects the format from the image data; saving uses the codec named by an
+// explicit format argument, whatever the file extension. The
+// special path "-" means stdin for loading and stdout for saving, which
+// lets goart be used as a pipeline stage (e.g. `cat in.jpg | goart generate - --output -`).
+package io
+
+import (
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+
+	"golang.org/x/image/bmp"
+)
+
+// Load reads an image from src. If src is "-", the image is read from
+// stdin and its format is detected automatically via image.Decode.
+func Load(src string) (image.Image, error) {
+	var r io.Reader
+
+	if src == "-" {
+		r = os.Stdin
+	} else {
+		file, err := os.Open(src)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	img, _, err := image.Decode(r)
+	return img, err
+}
+
+// Save encodes img and writes it to dest in the given format, using quality
+// (1-100) when format is "jpeg". If dest is "-", the image is written to
+// stdout. format is one of "png", "jpeg", "gif", or "bmp"; an unrecognized
+// format is an error.
+func Save(img image.Image, dest string, format string, quality int) error {
+	var w io.Writer
+
+	if dest == "-" {
+		w = os.Stdout
+	} else {
+		f, err := os.Create(dest)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch format {
+	case "png":
+		return png.Encode(w, img)
+	case "jpeg", "jpg":
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	case "gif":
+		return gif.Encode(w, img, nil)
+	case "bmp":
+		return bmp.Encode(w, img)
+	default:
+		return fmt.Errorf("unrecognized output format %q", format)
+	}
+}
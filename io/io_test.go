@@ -0,0 +1,105 @@
+/*
+MIT License
+
+Copyright (c) 2021 Jacob Salmela <me@jacobsalmela.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package io
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testImage() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 50), G: uint8(y * 50), B: 100, A: 255})
+		}
+	}
+	return img
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	for _, format := range []string{"png", "jpeg", "gif", "bmp"} {
+		t.Run(format, func(t *testing.T) {
+			dest := filepath.Join(t.TempDir(), "out."+format)
+			if err := Save(testImage(), dest, format, 90); err != nil {
+				t.Fatalf("Save returned an error: %v", err)
+			}
+
+			got, err := Load(dest)
+			if err != nil {
+				t.Fatalf("Load returned an error: %v", err)
+			}
+
+			bounds := got.Bounds()
+			if bounds.Dx() != 4 || bounds.Dy() != 4 {
+				t.Errorf("round-tripped image is %dx%d, want 4x4", bounds.Dx(), bounds.Dy())
+			}
+		})
+	}
+}
+
+func TestSaveUnrecognizedFormat(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "out.xyz")
+	if err := Save(testImage(), dest, "xyz", 90); err == nil {
+		t.Fatal("expected an error for an unrecognized format")
+	}
+}
+
+func TestSaveStdoutLoadStdin(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "piped.png")
+
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	origStdout := os.Stdout
+	os.Stdout = out
+	err = Save(testImage(), "-", "png", 90)
+	os.Stdout = origStdout
+	out.Close()
+	if err != nil {
+		t.Fatalf("Save to stdout returned an error: %v", err)
+	}
+
+	in, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to reopen temp file: %v", err)
+	}
+	defer in.Close()
+	origStdin := os.Stdin
+	os.Stdin = in
+	img, err := Load("-")
+	os.Stdin = origStdin
+	if err != nil {
+		t.Fatalf("Load from stdin returned an error: %v", err)
+	}
+
+	bounds := img.Bounds()
+	if bounds.Dx() != 4 || bounds.Dy() != 4 {
+		t.Errorf("round-tripped image is %dx%d, want 4x4", bounds.Dx(), bounds.Dy())
+	}
+}
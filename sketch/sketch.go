@@ -0,0 +1,199 @@
+/*
+MIT License
+
+Copyright (c) 2021 Jacob Salmela <me@jacobsalmela.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+// Package sketch implements goart's generative-art algorithm: repeatedly
+// sampling a color from a source image and painting a randomly jittered
+// polygon of that color onto a canvas, shrinking the stroke size and
+// increasing its opacity as the sketch progresses.
+package sketch
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"math/rand"
+
+	"github.com/fogleman/gg"
+)
+
+// UserParams holds all of the user-tunable parameters that control how a
+// Sketch is generated. It is built from command line flags in
+// cmd/generate.go and passed to NewSketch.
+type UserParams struct {
+	// StrokeRatio is the size of the initial stroke compared to that of the
+	// final result.
+	StrokeRatio float64
+	// DestWidth and DestHeight are the dimensions of the generated sketch.
+	DestWidth  int
+	DestHeight int
+	// InitialAlpha is the starting stroke transparency, between 0 and 1.
+	InitialAlpha float64
+	// StrokeReduction is the amount the stroke size is minimized by on each
+	// iteration.
+	StrokeReduction float64
+	// AlphaIncrease is the step of transparency increase at each iteration.
+	AlphaIncrease float64
+	// StrokeInversionThreshold is the minimum stroke size. Sketch does not
+	// read it: no borders are drawn around strokes at any stroke size.
+	StrokeInversionThreshold float64
+	// StrokeJitter is the deviation of a stroke from its projected position
+	// in the source image.
+	StrokeJitter int
+	// MinEdgeCount and MaxEdgeCount bound the number of edges of each
+	// stroke's polygon.
+	MinEdgeCount int
+	MaxEdgeCount int
+	// LuminanceScale, when enabled, scales each stroke's size by the
+	// brightness of the pixel it samples from the source image: brighter
+	// pixels produce larger polygons, darker pixels produce smaller ones,
+	// using the source as a luminance heatmap for shape density.
+	LuminanceScale bool
+	// Resampler, if set, is used to pre-scale the source image to
+	// DestWidth by DestHeight before strokes are sampled from it. If nil,
+	// the source is sampled at its native resolution and mapped onto the
+	// canvas proportionally, which is equivalent to nearest-neighbor
+	// sampling.
+	Resampler Resampler
+}
+
+// Sketch holds the state of an in-progress generative painting.
+type Sketch struct {
+	params UserParams
+	source image.Image
+	dc     *gg.Context
+	rng    *rand.Rand
+
+	strokeSize float64
+	alpha      float64
+}
+
+// NewSketch creates a Sketch that paints strokes sampled from source onto a
+// canvas of params.DestWidth by params.DestHeight. All of the Sketch's
+// randomness - jitter, edge count, and position sampling - is drawn from
+// rng, so two Sketches built from the same rng seed produce the same
+// result.
+func NewSketch(source image.Image, params UserParams, rng *rand.Rand) *Sketch {
+	if params.Resampler != nil {
+		source = params.Resampler.Resample(source, params.DestWidth, params.DestHeight)
+	}
+
+	dc := gg.NewContext(params.DestWidth, params.DestHeight)
+	dc.SetColor(color.White)
+	dc.Clear()
+
+	return &Sketch{
+		params:     params,
+		source:     source,
+		dc:         dc,
+		rng:        rng,
+		strokeSize: params.StrokeRatio,
+		alpha:      params.InitialAlpha,
+	}
+}
+
+// Update performs a single iteration of the painting algorithm: it samples a
+// color from the source image at a random position, draws a polygon stroke
+// of that color there with its vertices jittered by StrokeJitter, and
+// advances the stroke size and alpha for the next call.
+func (s *Sketch) Update() {
+	x := s.rng.Intn(s.params.DestWidth)
+	y := s.rng.Intn(s.params.DestHeight)
+
+	srcBounds := s.source.Bounds()
+	srcX := srcBounds.Min.X + x*srcBounds.Dx()/s.params.DestWidth
+	srcY := srcBounds.Min.Y + y*srcBounds.Dy()/s.params.DestHeight
+	c := s.source.At(srcX, srcY)
+
+	radius := s.strokeSize * float64(s.params.DestWidth)
+	if s.params.LuminanceScale {
+		// Brighter pixels paint larger strokes, darker pixels smaller ones,
+		// so the stroke sizes trace the tonal structure of the source.
+		radius *= luminance(c)
+	}
+
+	edgeCount := s.params.MinEdgeCount
+	if s.params.MaxEdgeCount > s.params.MinEdgeCount {
+		edgeCount += s.rng.Intn(s.params.MaxEdgeCount - s.params.MinEdgeCount + 1)
+	}
+
+	s.drawPolygon(x, y, radius, edgeCount, c)
+
+	s.strokeSize -= s.params.StrokeReduction
+	if s.strokeSize < 0 {
+		s.strokeSize = 0
+	}
+	s.alpha += s.params.AlphaIncrease
+	if s.alpha > 1 {
+		s.alpha = 1
+	}
+}
+
+// drawPolygon draws a regular n-edge polygon of the given color, centered at
+// (cx, cy) with the given radius and jittered by StrokeJitter.
+func (s *Sketch) drawPolygon(cx, cy int, radius float64, edgeCount int, c color.Color) {
+	s.dc.NewSubPath()
+	for i := 0; i < edgeCount; i++ {
+		angle := 2 * math.Pi * float64(i) / float64(edgeCount)
+
+		jitterX, jitterY := 0, 0
+		if s.params.StrokeJitter > 0 {
+			jitterX = s.rng.Intn(2*s.params.StrokeJitter) - s.params.StrokeJitter
+			jitterY = s.rng.Intn(2*s.params.StrokeJitter) - s.params.StrokeJitter
+		}
+
+		px := float64(cx+jitterX) + radius*math.Cos(angle)
+		py := float64(cy+jitterY) + radius*math.Sin(angle)
+		s.dc.LineTo(px, py)
+	}
+	s.dc.ClosePath()
+
+	r, g, b, _ := c.RGBA()
+	s.dc.SetRGBA(float64(r)/0xffff, float64(g)/0xffff, float64(b)/0xffff, s.alpha)
+	s.dc.Fill()
+}
+
+// Output returns the generated image.
+func (s *Sketch) Output() image.Image {
+	return s.dc.Image()
+}
+
+// Snapshot returns a copy of the canvas as it currently stands. Unlike
+// Output, the returned image is independent of the Sketch's internal
+// canvas, so it is safe to call Snapshot between calls to Update without
+// racing against further drawing.
+func (s *Sketch) Snapshot() image.Image {
+	src := s.dc.Image()
+	dst := image.NewRGBA(src.Bounds())
+	draw.Draw(dst, dst.Bounds(), src, src.Bounds().Min, draw.Src)
+	return dst
+}
+
+// luminance returns the Rec. 601 relative luminance of c, normalized to the
+// range [0, 1].
+func luminance(c color.Color) float64 {
+	r, g, b, _ := c.RGBA()
+	return (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 0xffff
+}
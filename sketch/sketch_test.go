@@ -0,0 +1,131 @@
+/*
+MIT License
+
+Copyright (c) 2021 Jacob Salmela <me@jacobsalmela.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package sketch
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+func solidImage(w, h int, c color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func countNonWhite(img image.Image) int {
+	bounds := img.Bounds()
+	n := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			if r != 0xffff || g != 0xffff || b != 0xffff {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func basicParams(lum bool) UserParams {
+	return UserParams{
+		StrokeRatio:    0.5,
+		DestWidth:      40,
+		DestHeight:     40,
+		InitialAlpha:   1,
+		MinEdgeCount:   3,
+		MaxEdgeCount:   3,
+		LuminanceScale: lum,
+	}
+}
+
+func TestSameSeedProducesIdenticalOutput(t *testing.T) {
+	source := solidImage(40, 40, color.Gray{Y: 128})
+
+	a := NewSketch(source, basicParams(false), rand.New(rand.NewSource(42)))
+	b := NewSketch(source, basicParams(false), rand.New(rand.NewSource(42)))
+
+	for i := 0; i < 20; i++ {
+		a.Update()
+		b.Update()
+	}
+
+	outA := a.Output().(*image.RGBA)
+	outB := b.Output().(*image.RGBA)
+	if len(outA.Pix) != len(outB.Pix) {
+		t.Fatalf("output sizes differ: %d vs %d bytes", len(outA.Pix), len(outB.Pix))
+	}
+	for i := range outA.Pix {
+		if outA.Pix[i] != outB.Pix[i] {
+			t.Fatalf("outputs diverged at byte %d: %d vs %d", i, outA.Pix[i], outB.Pix[i])
+		}
+	}
+}
+
+func TestDifferentSeedsProduceDifferentOutput(t *testing.T) {
+	source := solidImage(40, 40, color.Gray{Y: 128})
+
+	a := NewSketch(source, basicParams(false), rand.New(rand.NewSource(1)))
+	b := NewSketch(source, basicParams(false), rand.New(rand.NewSource(2)))
+
+	for i := 0; i < 20; i++ {
+		a.Update()
+		b.Update()
+	}
+
+	outA := a.Output().(*image.RGBA)
+	outB := b.Output().(*image.RGBA)
+	for i := range outA.Pix {
+		if outA.Pix[i] != outB.Pix[i] {
+			return
+		}
+	}
+	t.Fatal("expected different seeds to produce different output, but every byte matched")
+}
+
+func TestLuminanceScaleShrinksStrokesOnDarkPixels(t *testing.T) {
+	// Neither color is pure white, so a painted stroke is always
+	// distinguishable from the canvas's white background.
+	dark := solidImage(40, 40, color.Gray{Y: 10})
+	bright := solidImage(40, 40, color.Gray{Y: 200})
+
+	darkSketch := NewSketch(dark, basicParams(true), rand.New(rand.NewSource(7)))
+	brightSketch := NewSketch(bright, basicParams(true), rand.New(rand.NewSource(7)))
+
+	darkSketch.Update()
+	brightSketch.Update()
+
+	darkPixels := countNonWhite(darkSketch.Output())
+	brightPixels := countNonWhite(brightSketch.Output())
+
+	if darkPixels >= brightPixels {
+		t.Errorf("expected a stroke sampled from a dark pixel to cover fewer pixels than one from a bright pixel, got dark=%d bright=%d", darkPixels, brightPixels)
+	}
+}